@@ -59,6 +59,16 @@ func init() {
 	})
 }
 
+// controllerService only publishes the lifecycle events the generated
+// api/services/sandbox/v1 and api/events packages already define
+// (sandboxes/create, sandboxes/start, sandboxes/exit). A filtered
+// Subscribe(SubscribeRequest) returns (stream SandboxEvent) method, plus
+// the richer Created/Ready/Paused/Resumed/Removed/PluginStateChanged event
+// types it would stream, requires adding messages and an RPC to the
+// Controller service's .proto definitions and regenerating those packages;
+// neither the .proto sources nor the generated stage exist in this tree,
+// so that is out of scope here rather than something this package can
+// stub out against fabricated types.
 type controllerService struct {
 	local     sandbox.Controller
 	publisher events.Publisher
@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
 	"strings"
 
 	"dario.cat/mergo"
@@ -72,10 +71,107 @@ type Config struct {
 	ProxyPlugins map[string]ProxyPlugin `toml:"proxy_plugins"`
 	// Timeouts specified as a duration
 	Timeouts map[string]string `toml:"timeouts"`
-	// Imports are additional file path list to config files that can overwrite main config file fields
-	Imports []string `toml:"imports"`
+	// Imports are additional config files that can overwrite main config
+	// file fields. Each entry is either a plain local path/glob, or a
+	// struct form pointing at a remote source that must be pinned by
+	// sha256 (an https:// URL or an oci:// registry reference).
+	Imports []Import `toml:"imports"`
 	// StreamProcessors configuration
 	StreamProcessors map[string]StreamProcessor `toml:"stream_processors"`
+	// PluginPrivileges declares the host capabilities each plugin
+	// requests, keyed by plugin URI (io.containerd.x.vx.id). Proxy plugins
+	// may instead declare theirs in ProxyPlugin.Privileges. A plugin listed
+	// in neither place is assumed to request no privileges.
+	PluginPrivileges map[string]Privileges `toml:"plugin_privileges"`
+	// AllowedPrivileges is the daemon-wide ceiling that every declared
+	// plugin Privileges must fit within. A plugin whose privileges exceed
+	// this allowlist fails ValidateV2.
+	AllowedPrivileges Privileges `toml:"allowed_privileges"`
+}
+
+// Privileges describes the host capabilities a plugin needs in order to
+// run. It is used both to declare what a plugin requests (per plugin, via
+// Config.PluginPrivileges or ProxyPlugin.Privileges) and to declare the
+// daemon-wide ceiling those requests must stay within (Config.AllowedPrivileges).
+type Privileges struct {
+	// Network is one of "host", "none", or "bridge".
+	Network string `toml:"network"`
+	// Mounts are host paths the plugin may bind mount, each optionally
+	// suffixed with ":rw" to request write access (read-only otherwise).
+	Mounts []string `toml:"mounts"`
+	// Devices are host device paths the plugin may access.
+	Devices []string `toml:"devices"`
+	// Capabilities are Linux capabilities (e.g. "CAP_SYS_ADMIN") the
+	// plugin may hold.
+	Capabilities []string `toml:"capabilities"`
+	// GPU grants the plugin access to host GPU devices.
+	GPU bool `toml:"gpu"`
+}
+
+// networkRank orders network privilege levels from least to most
+// permissive, so a plugin requesting a level at or below what's allowed
+// is always permitted, regardless of which level the allowlist names.
+var networkRank = map[string]int{"none": 0, "bridge": 1, "host": 2}
+
+// exceeds reports whether p requests any capability not present in
+// allowed, returning a human-readable description of the first violation
+// found.
+func (p Privileges) exceeds(allowed Privileges) (string, bool) {
+	if p.Network != "" {
+		pr, pok := networkRank[p.Network]
+		ar, aok := networkRank[allowed.Network]
+		if !pok || !aok || pr > ar {
+			return fmt.Sprintf("network %q not permitted by allowed_privileges (%q)", p.Network, allowed.Network), true
+		}
+	}
+	allowedMounts := toSet(allowed.Mounts)
+	for _, m := range p.Mounts {
+		if _, ok := allowedMounts[m]; !ok {
+			return fmt.Sprintf("mount %q not permitted by allowed_privileges", m), true
+		}
+	}
+	allowedDevices := toSet(allowed.Devices)
+	for _, d := range p.Devices {
+		if _, ok := allowedDevices[d]; !ok {
+			return fmt.Sprintf("device %q not permitted by allowed_privileges", d), true
+		}
+	}
+	allowedCaps := toSet(allowed.Capabilities)
+	for _, c := range p.Capabilities {
+		if _, ok := allowedCaps[c]; !ok {
+			return fmt.Sprintf("capability %q not permitted by allowed_privileges", c), true
+		}
+	}
+	if p.GPU && !allowed.GPU {
+		return "gpu access not permitted by allowed_privileges", true
+	}
+	return "", false
+}
+
+// PrivilegesFor returns the declared privileges for the plugin with the
+// given URI, checking PluginPrivileges first and then ProxyPlugins.
+//
+// This only returns what ValidateV2 already checked against
+// AllowedPrivileges; it does not itself enforce anything. A subsystem that
+// launches a plugin (e.g. a sandbox controller) is expected to call this
+// before doing so and act on the result - no such caller exists in this
+// package yet.
+func (c *Config) PrivilegesFor(id string) Privileges {
+	if p, ok := c.PluginPrivileges[id]; ok {
+		return p
+	}
+	if pp, ok := c.ProxyPlugins[id]; ok {
+		return pp.Privileges
+	}
+	return Privileges{}
+}
+
+func toSet(s []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(s))
+	for _, v := range s {
+		set[v] = struct{}{}
+	}
+	return set
 }
 
 // StreamProcessor provides configuration for diff content processors
@@ -126,6 +222,37 @@ func (c *Config) ValidateV2() error {
 			return fmt.Errorf("invalid plugin key URI %q expect io.containerd.x.vx", p)
 		}
 	}
+	for name, pp := range c.ProxyPlugins {
+		if err := pp.validate(); err != nil {
+			return fmt.Errorf("invalid proxy plugin %q: %w", name, err)
+		}
+		if reason, ok := pp.Privileges.exceeds(c.AllowedPrivileges); ok {
+			return fmt.Errorf("proxy plugin %q requests disallowed privileges: %s", name, reason)
+		}
+	}
+	for id, priv := range c.PluginPrivileges {
+		if !strings.HasPrefix(id, "io.containerd.") || len(strings.SplitN(id, ".", 4)) < 4 {
+			return fmt.Errorf("invalid plugin_privileges key URI %q expect io.containerd.x.vx", id)
+		}
+		if reason, ok := priv.exceeds(c.AllowedPrivileges); ok {
+			return fmt.Errorf("plugin %q requests disallowed privileges: %s", id, reason)
+		}
+	}
+	return nil
+}
+
+// validate ensures a proxy plugin is addressed in exactly one way: either a
+// local socket Address, or an OCI Source to be resolved and launched by
+// containerd itself.
+func (p ProxyPlugin) validate() error {
+	switch {
+	case p.Address == "" && p.Source == "":
+		return errors.New("exactly one of address or source must be set")
+	case p.Address != "" && p.Source != "":
+		return errors.New("address and source are mutually exclusive")
+	case p.Source != "" && p.Digest == "":
+		return errors.New("digest is required when source is set")
+	}
 	return nil
 }
 
@@ -175,6 +302,32 @@ type ProxyPlugin struct {
 	Type     string `toml:"type"`
 	Address  string `toml:"address"`
 	Platform string `toml:"platform"`
+
+	// Source is an OCI reference the plugin binary/bundle is distributed
+	// as (e.g. "registry.example.com/my-snapshotter:v1"). Mutually
+	// exclusive with Address: if set, containerd resolves, pulls, and
+	// unpacks the reference before launching the plugin and wiring up
+	// Address itself.
+	Source string `toml:"source"`
+	// Digest pins the manifest digest that Source must resolve to. It is
+	// required whenever Source is set so that plugin installs are
+	// reproducible and verifiable.
+	Digest string `toml:"digest"`
+	// Env are additional environment variables passed to the plugin
+	// process launched from Source.
+	Env []string `toml:"env"`
+	// Args are additional arguments passed to the plugin binary launched
+	// from Source.
+	Args []string `toml:"args"`
+	// Privileges declares the host capabilities this plugin needs. It is
+	// checked against the daemon's AllowedPrivileges at load time.
+	Privileges Privileges `toml:"privileges"`
+}
+
+// isOCISourced reports whether the proxy plugin is distributed as an OCI
+// reference rather than addressed by a local socket.
+func (p ProxyPlugin) isOCISourced() bool {
+	return p.Source != ""
 }
 
 // Decode unmarshals a plugin specific configuration by plugin id
@@ -210,22 +363,46 @@ func (c *Config) Decode(ctx context.Context, p *plugin.Registration) (interface{
 	return p.Config, nil
 }
 
-// LoadConfig loads the containerd server config from the provided path
+// LoadConfig loads the containerd server config from the provided path and,
+// if it declares any OCI-sourced proxy plugins, resolves/pulls/launches
+// them. Use parseConfig instead if only parsing and validation are wanted,
+// without the side effect of installing and launching plugin processes -
+// Reload does this, since re-parsing on every reload must not re-launch
+// already-running proxy plugins.
 func LoadConfig(ctx context.Context, path string, out *Config) error {
+	if err := parseConfig(ctx, path, out); err != nil {
+		return err
+	}
+
+	if err := installOCIProxyPlugins(ctx, out); err != nil {
+		return fmt.Errorf("failed to install OCI-sourced proxy plugins: %w", err)
+	}
+
+	return nil
+}
+
+// parseConfig loads and validates the containerd server config from the
+// provided path, without installing or launching any OCI-sourced proxy
+// plugins it declares.
+func parseConfig(ctx context.Context, path string, out *Config) error {
 	if out == nil {
 		return fmt.Errorf("argument out must not be nil: %w", errdefs.ErrInvalidArgument)
 	}
 
 	var (
-		loaded  = map[string]bool{}
-		pending = []string{path}
+		loaded  = map[string]string{}
+		pending = []resolvedImport{{Key: path, Path: path}}
 	)
 
 	for len(pending) > 0 {
-		path, pending = pending[0], pending[1:]
-
-		// Check if a file at the given path already loaded to prevent circular imports
-		if _, ok := loaded[path]; ok {
+		var next resolvedImport
+		next, pending = pending[0], pending[1:]
+		path = next.Path
+
+		// Check if an import with this key already loaded to prevent circular imports.
+		// The key is the resolved digest for remote imports, so the same remote
+		// content reached via two different references is still only loaded once.
+		if _, ok := loaded[next.Key]; ok {
 			continue
 		}
 
@@ -238,25 +415,26 @@ func LoadConfig(ctx context.Context, path string, out *Config) error {
 			return err
 		}
 
-		imports, err := resolveImports(path, config.Imports)
+		imports, err := resolveImports(ctx, path, out.TempDir, config.Imports)
 		if err != nil {
 			return err
 		}
 
-		loaded[path] = true
+		loaded[next.Key] = path
 		pending = append(pending, imports...)
 	}
 
 	// Fix up the list of config files loaded
-	out.Imports = []string{}
-	for path := range loaded {
-		out.Imports = append(out.Imports, path)
+	out.Imports = nil
+	for _, loadedPath := range loaded {
+		out.Imports = append(out.Imports, Import{Path: loadedPath})
 	}
 
 	err := out.ValidateV2()
 	if err != nil {
 		return fmt.Errorf("failed to load TOML from %s: %w", path, err)
 	}
+
 	return nil
 }
 
@@ -309,34 +487,6 @@ func loadConfigFile(ctx context.Context, path string) (*Config, error) {
 	return config, nil
 }
 
-// resolveImports resolves import strings list to absolute paths list:
-// - If path contains *, glob pattern matching applied
-// - Non abs path is relative to parent config file directory
-// - Abs paths returned as is
-func resolveImports(parent string, imports []string) ([]string, error) {
-	var out []string
-
-	for _, path := range imports {
-		if strings.Contains(path, "*") {
-			matches, err := filepath.Glob(path)
-			if err != nil {
-				return nil, err
-			}
-
-			out = append(out, matches...)
-		} else {
-			path = filepath.Clean(path)
-			if !filepath.IsAbs(path) {
-				path = filepath.Join(filepath.Dir(parent), path)
-			}
-
-			out = append(out, path)
-		}
-	}
-
-	return out, nil
-}
-
 // mergeConfig merges Config structs with the following rules:
 // 'to'         'from'      'result'
 // ""           "value"     "value"
@@ -369,6 +519,10 @@ func mergeConfig(to, from *Config) error {
 		to.Timeouts[k] = v
 	}
 
+	for k, v := range from.PluginPrivileges {
+		to.PluginPrivileges[k] = v
+	}
+
 	return nil
 }
 
@@ -0,0 +1,71 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import "testing"
+
+func TestPrivilegesExceedsNetwork(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		request  string
+		allowed  string
+		wantFail bool
+	}{
+		{name: "equal bridge", request: "bridge", allowed: "bridge"},
+		{name: "none under bridge", request: "none", allowed: "bridge"},
+		{name: "none under host", request: "none", allowed: "host"},
+		{name: "bridge under host", request: "bridge", allowed: "host"},
+		{name: "bridge over none", request: "bridge", allowed: "none", wantFail: true},
+		{name: "host over bridge", request: "host", allowed: "bridge", wantFail: true},
+		{name: "host over none", request: "host", allowed: "none", wantFail: true},
+		{name: "unset request always ok", request: "", allowed: "none"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			p := Privileges{Network: test.request}
+			allowed := Privileges{Network: test.allowed}
+
+			_, failed := p.exceeds(allowed)
+			if failed != test.wantFail {
+				t.Fatalf("exceeds(%+v, %+v) failed=%v, want %v", p, allowed, failed, test.wantFail)
+			}
+		})
+	}
+}
+
+func TestPrivilegesExceedsListFields(t *testing.T) {
+	allowed := Privileges{
+		Mounts:       []string{"/dev/foo"},
+		Devices:      []string{"/dev/bar"},
+		Capabilities: []string{"CAP_NET_ADMIN"},
+	}
+
+	if _, failed := (Privileges{Mounts: []string{"/dev/foo"}}).exceeds(allowed); failed {
+		t.Fatal("expected allowed mount to be permitted")
+	}
+	if _, failed := (Privileges{Mounts: []string{"/dev/other"}}).exceeds(allowed); !failed {
+		t.Fatal("expected undeclared mount to be rejected")
+	}
+	if _, failed := (Privileges{Devices: []string{"/dev/other"}}).exceeds(allowed); !failed {
+		t.Fatal("expected undeclared device to be rejected")
+	}
+	if _, failed := (Privileges{Capabilities: []string{"CAP_SYS_ADMIN"}}).exceeds(allowed); !failed {
+		t.Fatal("expected undeclared capability to be rejected")
+	}
+	if _, failed := (Privileges{GPU: true}).exceeds(allowed); !failed {
+		t.Fatal("expected gpu access to be rejected when not allowed")
+	}
+}
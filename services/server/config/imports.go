@@ -0,0 +1,221 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Import is a single entry in Config.Imports. It unmarshals from either a
+// plain TOML string (a local path or glob, resolved relative to the
+// importing file) or a table with explicit path and sha256 fields:
+//
+//	imports = ["./conf.d/*.toml"]
+//	[[imports]]
+//	path = "https://example.com/base.toml"
+//	sha256 = "e3b0c4..."
+type Import struct {
+	// Path is a local filesystem path/glob, an https:// URL, or an
+	// oci://registry/repo:tag reference.
+	Path string `toml:"path"`
+	// SHA256 pins the digest of the fetched content. Required whenever
+	// Path is a remote (https:// or oci://) reference.
+	SHA256 string `toml:"sha256"`
+}
+
+// UnmarshalTOML implements the go-toml/v2 unmarshaler interface so that an
+// import entry may be given as either a bare string or a {path, sha256} table.
+func (i *Import) UnmarshalTOML(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		i.Path = v
+	case map[string]interface{}:
+		path, _ := v["path"].(string)
+		sha, _ := v["sha256"].(string)
+		i.Path = path
+		i.SHA256 = sha
+	default:
+		return fmt.Errorf("invalid import entry %#v: expected a string or a {path, sha256} table", value)
+	}
+	return nil
+}
+
+// resolvedImport is a loaded import ready to be read off disk, along with
+// the key used to dedupe it against circular/repeated imports.
+type resolvedImport struct {
+	// Key identifies this import for the circular-import guard: the
+	// resolved content digest for remote imports, or the local path it
+	// resolved to (a glob match, or a plain path joined onto the
+	// importing file's directory) for local ones.
+	Key string
+	// Path is the local filesystem path loadConfigFile should read,
+	// which for remote imports is the cache file they were fetched into.
+	Path string
+}
+
+// resolveImports resolves a parent file's import list into the concrete
+// files they refer to:
+//   - A path containing "*" is expanded via glob.
+//   - A plain relative path is resolved relative to the parent file's directory.
+//   - An "https://" or "oci://" entry is fetched, verified against its
+//     required sha256, and cached under tempDir/imports/<digest>.toml.
+func resolveImports(ctx context.Context, parent, tempDir string, imports []Import) ([]resolvedImport, error) {
+	var out []resolvedImport
+
+	for _, imp := range imports {
+		switch {
+		case strings.HasPrefix(imp.Path, "https://"):
+			if imp.SHA256 == "" {
+				return nil, fmt.Errorf("import %q must set sha256 to be fetched over https", imp.Path)
+			}
+			cached, err := fetchHTTPImport(ctx, imp.Path, imp.SHA256, tempDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import %q: %w", imp.Path, err)
+			}
+			out = append(out, resolvedImport{Key: "sha256:" + imp.SHA256, Path: cached})
+
+		case strings.HasPrefix(imp.Path, "oci://"):
+			if imp.SHA256 == "" {
+				return nil, fmt.Errorf("import %q must set sha256 to be fetched from a registry", imp.Path)
+			}
+			cached, err := fetchOCIImport(ctx, strings.TrimPrefix(imp.Path, "oci://"), imp.SHA256, tempDir)
+			if err != nil {
+				return nil, fmt.Errorf("failed to import %q: %w", imp.Path, err)
+			}
+			out = append(out, resolvedImport{Key: "sha256:" + imp.SHA256, Path: cached})
+
+		case strings.Contains(imp.Path, "*"):
+			matches, err := filepath.Glob(imp.Path)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range matches {
+				out = append(out, resolvedImport{Key: m, Path: m})
+			}
+
+		default:
+			path := filepath.Clean(imp.Path)
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(filepath.Dir(parent), path)
+			}
+			out = append(out, resolvedImport{Key: path, Path: path})
+		}
+	}
+
+	return out, nil
+}
+
+// fetchHTTPImport downloads url, verifies its sha256 against want, and
+// caches it under tempDir/imports/<digest>.toml, returning that path. If
+// the cache file already exists it is reused without re-fetching.
+func fetchHTTPImport(ctx context.Context, url, want, tempDir string) (string, error) {
+	dest := filepath.Join(tempDir, "imports", want+".toml")
+	if _, err := os.Stat(dest); err == nil {
+		return dest, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if err := verifyAndCache(body, want, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// fetchOCIImport resolves ref through the same distribution stack used for
+// image pulls, verifies the resolved descriptor's digest against want,
+// fetches its content, and caches it under tempDir/imports/<digest>.toml.
+//
+// It resolves through defaultOCIResolver rather than constructing its own
+// bare docker.Resolver, so an oci:// import honors whatever registry
+// hosts, mirrors, and auth the daemon has already configured via
+// SetOCIResolver instead of silently falling back to an anonymous,
+// unconfigured client.
+func fetchOCIImport(ctx context.Context, ref, want, tempDir string) (string, error) {
+	dest := filepath.Join(tempDir, "imports", want+".toml")
+	if _, err := os.Stat(dest); err == nil {
+		return dest, nil
+	}
+
+	resolver := defaultOCIResolver
+	name, desc, err := resolver.Resolve(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve: %w", err)
+	}
+	if desc.Digest.Encoded() != want {
+		return "", fmt.Errorf("resolved digest %s does not match required sha256:%s", desc.Digest, want)
+	}
+
+	fetcher, err := resolver.Fetcher(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	rc, err := fetcher.Fetch(ctx, desc)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+
+	body, err := io.ReadAll(rc)
+	if err != nil {
+		return "", err
+	}
+
+	if err := verifyAndCache(body, want, dest); err != nil {
+		return "", err
+	}
+	return dest, nil
+}
+
+// verifyAndCache checks that body hashes to want (a hex sha256) and, if
+// so, writes it to dest, creating its parent directory as needed.
+func verifyAndCache(body []byte, want, dest string) error {
+	sum := sha256.Sum256(body)
+	got := hex.EncodeToString(sum[:])
+	if got != want {
+		return fmt.Errorf("sha256 mismatch: got %s, want %s", got, want)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0711); err != nil {
+		return err
+	}
+	return os.WriteFile(dest, body, 0600)
+}
@@ -0,0 +1,90 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveImportsLocal(t *testing.T) {
+	dir := t.TempDir()
+	parent := filepath.Join(dir, "config.toml")
+
+	for _, name := range []string{"a.toml", "b.toml"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(""), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := resolveImports(context.Background(), parent, dir, []Import{
+		{Path: "./a.toml"},
+		{Path: filepath.Join(dir, "*.toml")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(out) != 3 {
+		t.Fatalf("expected 3 resolved imports (1 relative + 2 glob matches), got %d: %+v", len(out), out)
+	}
+	for _, imp := range out {
+		if !filepath.IsAbs(imp.Path) {
+			t.Fatalf("expected resolved import path to be absolute, got %q", imp.Path)
+		}
+	}
+}
+
+func TestResolveImportsRemoteRequiresSHA256(t *testing.T) {
+	for _, path := range []string{"https://example.com/base.toml", "oci://example.com/base:v1"} {
+		_, err := resolveImports(context.Background(), "/etc/containerd/config.toml", t.TempDir(), []Import{{Path: path}})
+		if err == nil {
+			t.Fatalf("expected error for %q without sha256", path)
+		}
+	}
+}
+
+func TestVerifyAndCache(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "imports", "example.toml")
+	body := []byte("version = 2\n")
+
+	const wrong = "0000000000000000000000000000000000000000000000000000000000000000"
+	if err := verifyAndCache(body, wrong, dest); err == nil {
+		t.Fatal("expected mismatch error for a made-up digest")
+	}
+	if _, err := os.Stat(dest); err == nil {
+		t.Fatal("expected nothing to be cached on a digest mismatch")
+	}
+
+	sum := sha256.Sum256(body)
+	want := hex.EncodeToString(sum[:])
+	if err := verifyAndCache(body, want, dest); err != nil {
+		t.Fatalf("expected matching digest to succeed: %v", err)
+	}
+	got, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(body) {
+		t.Fatalf("cached content = %q, want %q", got, body)
+	}
+}
@@ -0,0 +1,272 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/containerd/containerd/archive"
+	"github.com/containerd/containerd/archive/compression"
+	"github.com/containerd/containerd/content"
+	"github.com/containerd/containerd/content/local"
+	"github.com/containerd/containerd/images"
+	"github.com/containerd/containerd/platforms"
+	"github.com/containerd/containerd/remotes"
+	"github.com/containerd/containerd/remotes/docker"
+	"github.com/containerd/log"
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// OCIPluginInstaller resolves ProxyPlugin entries whose Source is an OCI
+// reference, pulls them into the content store, and unpacks them into
+// PluginDir so they can be launched like any other out-of-tree plugin.
+//
+// It is a thin wrapper around containerd's existing distribution stack:
+// the installer does not implement its own registry client, it only drives
+// the Resolver/Fetcher it is given.
+type OCIPluginInstaller struct {
+	Store    content.Store
+	Resolver remotes.Resolver
+	// PluginDir is the directory bundles are unpacked under, one
+	// subdirectory per resolved digest.
+	PluginDir string
+}
+
+// InstalledPlugin describes a ProxyPlugin that has been resolved, pulled,
+// and unpacked on disk, ready to be launched.
+type InstalledPlugin struct {
+	ProxyPlugin
+	// Dir is PluginDir/<digest>, the unpack destination of the bundle.
+	Dir string
+	// Digest is the verified manifest digest the bundle was pulled at.
+	Digest digest.Digest
+}
+
+// Install resolves pp.Source, verifies the resolved manifest digest
+// matches pp.Digest, fetches and unpacks the artifact into
+// PluginDir/<digest>, and returns the on-disk location of the bundle.
+//
+// Install is idempotent: if the target directory already exists it is
+// assumed to contain a previously verified unpack and is reused as-is.
+func (i *OCIPluginInstaller) Install(ctx context.Context, id string, pp ProxyPlugin) (*InstalledPlugin, error) {
+	if pp.Digest == "" {
+		return nil, fmt.Errorf("refusing to install proxy plugin %q without a pinned digest", id)
+	}
+	pin, err := digest.Parse(pp.Digest)
+	if err != nil {
+		return nil, fmt.Errorf("proxy plugin %q has an invalid digest %q: %w", id, pp.Digest, err)
+	}
+
+	platform := platforms.Default()
+	if pp.Platform != "" {
+		spec, err := platforms.Parse(pp.Platform)
+		if err != nil {
+			return nil, fmt.Errorf("proxy plugin %q has an invalid platform %q: %w", id, pp.Platform, err)
+		}
+		platform = platforms.Only(spec)
+	}
+
+	resolvedName, desc, err := i.Resolver.Resolve(ctx, pp.Source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve proxy plugin source %q: %w", pp.Source, err)
+	}
+	if desc.Digest != pin {
+		return nil, fmt.Errorf("proxy plugin %q resolved to digest %s, expected %s", id, desc.Digest, pin)
+	}
+
+	dir := filepath.Join(i.PluginDir, desc.Digest.Encoded())
+	if dirExists(dir) {
+		return &InstalledPlugin{ProxyPlugin: pp, Dir: dir, Digest: desc.Digest}, nil
+	}
+
+	fetcher, err := i.Resolver.Fetcher(ctx, resolvedName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create fetcher for proxy plugin %q: %w", id, err)
+	}
+
+	handler := images.Handlers(
+		remotes.FetchHandler(i.Store, fetcher),
+		images.ChildrenHandler(i.Store),
+	)
+	if err := images.Dispatch(ctx, handler, nil, desc); err != nil {
+		return nil, fmt.Errorf("failed to fetch proxy plugin %q: %w", id, err)
+	}
+
+	if err := unpackBundle(ctx, i.Store, desc, dir, platform); err != nil {
+		return nil, fmt.Errorf("failed to unpack proxy plugin %q into %s: %w", id, dir, err)
+	}
+
+	return &InstalledPlugin{ProxyPlugin: pp, Dir: dir, Digest: desc.Digest}, nil
+}
+
+// unpackBundle extracts the content-addressed plugin artifact referenced by
+// desc into dir. desc is expected to be an image manifest (or manifest
+// list); each of its layers is applied in order, the same way containerd's
+// rootfs unpack path applies image layers.
+func unpackBundle(ctx context.Context, store content.Store, desc ocispec.Descriptor, dir string, platform platforms.MatchComparer) error {
+	manifest, err := images.Manifest(ctx, store, desc, platform)
+	if err != nil {
+		return fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0711); err != nil {
+		return err
+	}
+
+	for _, layer := range manifest.Layers {
+		if err := applyLayer(ctx, store, layer, dir); err != nil {
+			return fmt.Errorf("failed to apply layer %s: %w", layer.Digest, err)
+		}
+	}
+
+	return nil
+}
+
+func applyLayer(ctx context.Context, store content.Store, layer ocispec.Descriptor, dir string) error {
+	ra, err := store.ReaderAt(ctx, layer)
+	if err != nil {
+		return err
+	}
+	defer ra.Close()
+
+	ds, err := compression.DecompressStream(content.NewReader(ra))
+	if err != nil {
+		return err
+	}
+	defer ds.Close()
+
+	_, err = archive.Apply(ctx, dir, ds)
+	return err
+}
+
+// dirExists reports whether dir already exists, used to make Install a
+// no-op for a bundle that was previously resolved and unpacked.
+func dirExists(dir string) bool {
+	_, err := os.Stat(dir)
+	return err == nil
+}
+
+// InstallProxyPlugins installs every OCI-sourced entry of cfg.ProxyPlugins
+// via installer, launches its plugin binary, and rewrites the entry's
+// Address in place to the socket the launched process is listening on.
+// It is a no-op for proxy plugins addressed directly via Address.
+//
+// The daemon must call this after the content store is available and
+// before initializing any plugin that Requires the proxy plugin being
+// installed, so the socket is already wired into ProxyPlugins by the time
+// dependents look it up.
+func InstallProxyPlugins(ctx context.Context, cfg *Config, installer *OCIPluginInstaller) error {
+	for id, pp := range cfg.ProxyPlugins {
+		if !pp.isOCISourced() {
+			continue
+		}
+
+		installed, err := installer.Install(ctx, id, pp)
+		if err != nil {
+			return fmt.Errorf("failed to install proxy plugin %q: %w", id, err)
+		}
+
+		addr, err := launchPluginBinary(ctx, id, installed)
+		if err != nil {
+			return fmt.Errorf("failed to launch proxy plugin %q: %w", id, err)
+		}
+
+		pp.Address = addr
+		cfg.ProxyPlugins[id] = pp
+	}
+
+	return nil
+}
+
+// launchPluginBinary starts the bin/<id> binary unpacked from an OCI proxy
+// plugin bundle and returns the unix socket address it was told to listen
+// on via LISTEN_ADDRESS.
+func launchPluginBinary(ctx context.Context, id string, ip *InstalledPlugin) (string, error) {
+	bin := filepath.Join(ip.Dir, "bin", id)
+	if _, err := os.Stat(bin); err != nil {
+		return "", fmt.Errorf("plugin bundle %s does not contain expected binary %s: %w", ip.Dir, bin, err)
+	}
+
+	addr := filepath.Join(ip.Dir, id+".sock")
+
+	cmd := exec.Command(bin, ip.Args...)
+	cmd.Env = append(os.Environ(), ip.Env...)
+	cmd.Env = append(cmd.Env, "LISTEN_ADDRESS="+addr)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.G(ctx).WithField("plugin", id).WithError(err).Warn("proxy plugin process exited")
+		}
+	}()
+
+	return addr, nil
+}
+
+// installOCIProxyPlugins is called by LoadConfig once the config has been
+// merged and validated, so that OCI-sourced proxy plugins are resolved,
+// pulled, and launched as part of the same daemon-start sequence that
+// reads the config, before plugin registration gets to plugins that
+// Require them.
+func installOCIProxyPlugins(ctx context.Context, cfg *Config) error {
+	var hasOCISource bool
+	for _, pp := range cfg.ProxyPlugins {
+		if pp.isOCISourced() {
+			hasOCISource = true
+			break
+		}
+	}
+	if !hasOCISource {
+		return nil
+	}
+
+	store, err := local.NewStore(filepath.Join(cfg.Root, "content"))
+	if err != nil {
+		return fmt.Errorf("failed to open content store: %w", err)
+	}
+
+	return InstallProxyPlugins(ctx, cfg, &OCIPluginInstaller{
+		Store:     store,
+		Resolver:  defaultOCIResolver,
+		PluginDir: cfg.PluginDir,
+	})
+}
+
+// defaultOCIResolver is used to resolve OCI-sourced proxy plugins
+// (installOCIProxyPlugins) and oci:// config imports (fetchOCIImport)
+// until the daemon calls SetOCIResolver with one that knows about its
+// configured registry hosts, mirrors, and auth.
+var defaultOCIResolver remotes.Resolver = docker.NewResolver(docker.ResolverOptions{})
+
+// SetOCIResolver overrides defaultOCIResolver. The daemon should call this
+// during startup, once it has built a resolver from its registry hosts
+// configuration, so that oci:// proxy plugins and config imports are
+// resolved the same way as any other image pull rather than through an
+// anonymous, unconfigured client.
+func SetOCIResolver(r remotes.Resolver) {
+	defaultOCIResolver = r
+}
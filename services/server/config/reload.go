@@ -0,0 +1,142 @@
+/*
+   Copyright The containerd Authors.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Reloadable is implemented by a plugin instance that can accept a new
+// configuration blob in place rather than requiring a daemon restart.
+// Plugins that do not implement Reloadable are left untouched by Reload
+// even if their Plugins[id] entry changed; such a change is reported in
+// ReloadDiff.SkippedPlugins instead of being applied.
+type Reloadable interface {
+	// Reload is called with the freshly decoded plugin configuration for
+	// this plugin's ID. It must apply the new configuration atomically;
+	// returning an error aborts the whole Reload call.
+	Reload(ctx context.Context, config interface{}) error
+}
+
+// PluginReloader resolves a plugin ID to its running instance, if that
+// instance implements Reloadable. It is satisfied by the daemon's plugin
+// registry.
+type PluginReloader func(id string) (Reloadable, bool)
+
+// ReloadDiff is the structured diff between the active configuration and
+// the one just loaded from disk, computed by Reload.
+type ReloadDiff struct {
+	// Changed lists the reloadable sections that were applied.
+	Changed []string
+	// ChangedPlugins lists the Plugins[id] entries that changed and were
+	// applied because their running instance implements Reloadable.
+	ChangedPlugins []string
+	// SkippedPlugins lists Plugins[id] entries that changed on disk but
+	// were left in place because the running instance isn't Reloadable.
+	SkippedPlugins []string
+}
+
+// Reload re-reads the config file at path, validates it, and applies any
+// changes to sections marked reloadable (Debug, Metrics.Address,
+// StreamProcessors, Timeouts, and any Plugins[id] entry whose plugin
+// implements Reloadable) directly onto current.
+//
+// If the new config differs from current in any non-reloadable field
+// (Root, State, GRPC.Address, TTRPC.Address), Reload fails atomically:
+// current is left completely untouched and no partial application occurs.
+//
+// Reload deliberately calls parseConfig rather than LoadConfig: re-parsing
+// on every reload must not re-trigger installation of OCI-sourced proxy
+// plugins, which would relaunch an already-running plugin process against
+// the socket path the prior instance is still bound to. Reload has no diff
+// logic for ProxyPlugins yet, so a change there is neither applied nor
+// reported - it still requires a daemon restart.
+//
+// Reload only handles the load/validate/diff/apply step; it does not
+// itself install a SIGHUP handler or expose status over the introspection
+// API. The daemon is expected to call this from its signal handler and to
+// store the returned ReloadDiff wherever it serves reload status from -
+// neither of those callers exist in this package yet.
+func Reload(ctx context.Context, path string, current *Config, resolve PluginReloader) (*ReloadDiff, error) {
+	fresh := &Config{}
+	if err := parseConfig(ctx, path, fresh); err != nil {
+		return nil, fmt.Errorf("failed to load config for reload: %w", err)
+	}
+	// parseConfig already calls ValidateV2 on fresh, but reload is
+	// security-sensitive enough (privilege allowlists, proxy plugin
+	// source/digest pairing) to assert that explicitly rather than rely
+	// on it being validated as a side effect of loading.
+	if err := fresh.ValidateV2(); err != nil {
+		return nil, fmt.Errorf("refusing to reload invalid config: %w", err)
+	}
+
+	var blocked []string
+	if fresh.Root != current.Root {
+		blocked = append(blocked, "root")
+	}
+	if fresh.State != current.State {
+		blocked = append(blocked, "state")
+	}
+	if fresh.GRPC.Address != current.GRPC.Address {
+		blocked = append(blocked, "grpc.address")
+	}
+	if fresh.TTRPC.Address != current.TTRPC.Address {
+		blocked = append(blocked, "ttrpc.address")
+	}
+	if len(blocked) > 0 {
+		return nil, fmt.Errorf("cannot reload: %s require a daemon restart", strings.Join(blocked, ", "))
+	}
+
+	diff := &ReloadDiff{}
+
+	if !reflect.DeepEqual(fresh.Debug, current.Debug) {
+		current.Debug = fresh.Debug
+		diff.Changed = append(diff.Changed, "debug")
+	}
+	if fresh.Metrics.Address != current.Metrics.Address {
+		current.Metrics.Address = fresh.Metrics.Address
+		diff.Changed = append(diff.Changed, "metrics.address")
+	}
+	if !reflect.DeepEqual(fresh.StreamProcessors, current.StreamProcessors) {
+		current.StreamProcessors = fresh.StreamProcessors
+		diff.Changed = append(diff.Changed, "stream_processors")
+	}
+	if !reflect.DeepEqual(fresh.Timeouts, current.Timeouts) {
+		current.Timeouts = fresh.Timeouts
+		diff.Changed = append(diff.Changed, "timeouts")
+	}
+
+	for id, blob := range fresh.Plugins {
+		if reflect.DeepEqual(blob, current.Plugins[id]) {
+			continue
+		}
+		if r, ok := resolve(id); ok {
+			if err := r.Reload(ctx, blob); err != nil {
+				return nil, fmt.Errorf("failed to reload plugin %q: %w", id, err)
+			}
+			current.Plugins[id] = blob
+			diff.ChangedPlugins = append(diff.ChangedPlugins, id)
+		} else {
+			diff.SkippedPlugins = append(diff.SkippedPlugins, id)
+		}
+	}
+
+	return diff, nil
+}